@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -9,117 +10,189 @@ import (
 )
 
 type DeploymentsCollector struct {
-	deploymentReleaseInfoMetric                *prometheus.GaugeVec
-	deploymentStemcellInfoMetric               *prometheus.GaugeVec
-	deploymentVMTypeCountMetric                *prometheus.GaugeVec
+	deploymentReleaseInfoDesc                  *prometheus.Desc
+	deploymentReleaseCreatedDesc               *prometheus.Desc
+	deploymentStemcellInfoDesc                 *prometheus.Desc
+	deploymentStemcellCreatedDesc              *prometheus.Desc
+	deploymentInstanceCountDesc                *prometheus.Desc
+	jobProcessCPUTotalDesc                     *prometheus.Desc
+	jobProcessMemKBDesc                        *prometheus.Desc
+	jobProcessUptimeSecondsDesc                *prometheus.Desc
+	jobProcessHealthyDesc                      *prometheus.Desc
+	lastDeploymentsScrapeErrorDesc             *prometheus.Desc
+	scrapeStaleMetric                          prometheus.Gauge
 	lastDeploymentsScrapeTimestampMetric       prometheus.Gauge
 	lastDeploymentsScrapeDurationSecondsMetric prometheus.Gauge
+	logger                                     *slog.Logger
+	openMetricsEnabled                         bool
+	namespace                                  string
 }
 
+// NewDeploymentsCollector creates a DeploymentsCollector. When
+// openMetricsEnabled is true (the exporter's --web.openmetrics flag),
+// Collect also emits companion `_created` upload timestamps for
+// deployment_release_info and deployment_stemcell_info. Collect renders
+// the info series themselves as gauges with a constant '1' value either
+// way; WriteOpenMetricsInfo renders them as OpenMetrics Info metrics.
 func NewDeploymentsCollector(
 	namespace string,
 	environment string,
 	boshName string,
 	boshUUID string,
+	logger *slog.Logger,
+	openMetricsEnabled bool,
 ) *DeploymentsCollector {
-	deploymentReleaseInfoMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "deployment",
-			Name:      "release_info",
-			Help:      "Labeled BOSH Deployment Release Info with a constant '1' value.",
-			ConstLabels: prometheus.Labels{
-				"environment": environment,
-				"bosh_name":   boshName,
-				"bosh_uuid":   boshUUID,
-			},
-		},
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	constLabels := prometheus.Labels{
+		"environment": environment,
+		"bosh_name":   boshName,
+		"bosh_uuid":   boshUUID,
+	}
+
+	deploymentReleaseInfoDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "release_info"),
+		"Labeled BOSH Deployment Release Info with a constant '1' value.",
 		[]string{"bosh_deployment", "bosh_release_name", "bosh_release_version"},
+		constLabels,
 	)
 
-	deploymentStemcellInfoMetric := prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "deployment",
-			Name:      "stemcell_info",
-			Help:      "Labeled BOSH Deployment Stemcell Info with a constant '1' value.",
-			ConstLabels: prometheus.Labels{
-				"environment": environment,
-				"bosh_name":   boshName,
-				"bosh_uuid":   boshUUID,
-			},
-		},
+	deploymentReleaseCreatedDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "release_info_created"),
+		"Unix timestamp of when this BOSH Release version was uploaded to the Director.",
+		[]string{"bosh_deployment", "bosh_release_name", "bosh_release_version"},
+		constLabels,
+	)
+
+	deploymentStemcellInfoDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "stemcell_info"),
+		"Labeled BOSH Deployment Stemcell Info with a constant '1' value.",
 		[]string{"bosh_deployment", "bosh_stemcell_name", "bosh_stemcell_version", "bosh_stemcell_os_name"},
+		constLabels,
+	)
+
+	deploymentStemcellCreatedDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "stemcell_info_created"),
+		"Unix timestamp of when this BOSH Stemcell version was uploaded to the Director.",
+		[]string{"bosh_deployment", "bosh_stemcell_name", "bosh_stemcell_version", "bosh_stemcell_os_name"},
+		constLabels,
+	)
+
+	deploymentInstanceCountDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "instance_count"),
+		"Number of instances in this deployment",
+		[]string{"bosh_deployment", "bosh_vm_type"},
+		constLabels,
+	)
+
+	jobProcessCPUTotalDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "process_cpu_total"),
+		"Total CPU time consumed by this job process, in seconds.",
+		[]string{"bosh_deployment", "bosh_job_name", "bosh_job_id", "process"},
+		constLabels,
+	)
+
+	jobProcessMemKBDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "process_mem_kb"),
+		"Resident memory used by this job process, in KB.",
+		[]string{"bosh_deployment", "bosh_job_name", "bosh_job_id", "process"},
+		constLabels,
+	)
+
+	jobProcessUptimeSecondsDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "process_uptime_seconds"),
+		"Number of seconds this job process has been running.",
+		[]string{"bosh_deployment", "bosh_job_name", "bosh_job_id", "process"},
+		constLabels,
+	)
+
+	jobProcessHealthyDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "process_healthy"),
+		"Whether this job process is reported running (1 for running, 0 for not running).",
+		[]string{"bosh_deployment", "bosh_job_name", "bosh_job_id", "process"},
+		constLabels,
 	)
 
-	deploymentInstanceCountMetric := prometheus.NewGaugeVec(
+	lastDeploymentsScrapeErrorDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_deployments_scrape_error"),
+		"Whether the last scrape of Deployments metrics from BOSH failed (1 for failed, 0 for success).",
+		[]string{"bosh_deployment"},
+		constLabels,
+	)
+
+	scrapeStaleMetric := prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "deployment",
-			Name:      "instance_count",
-			Help:      "Number of instances in this deployment",
-			ConstLabels: prometheus.Labels{
-				"environment": environment,
-				"bosh_name":   boshName,
-				"bosh_uuid":   boshUUID,
-			},
+			Namespace:   namespace,
+			Subsystem:   "",
+			Name:        "scrape_stale",
+			Help:        "Whether this scrape served a stale snapshot because the BOSH Director client breaker is open (1 for stale, 0 for fresh).",
+			ConstLabels: constLabels,
 		},
-		[]string{"bosh_deployment", "bosh_vm_type"},
 	)
 
 	lastDeploymentsScrapeTimestampMetric := prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "",
-			Name:      "last_deployments_scrape_timestamp",
-			Help:      "Number of seconds since 1970 since last scrape of Deployments metrics from BOSH.",
-			ConstLabels: prometheus.Labels{
-				"environment": environment,
-				"bosh_name":   boshName,
-				"bosh_uuid":   boshUUID,
-			},
+			Namespace:   namespace,
+			Subsystem:   "",
+			Name:        "last_deployments_scrape_timestamp",
+			Help:        "Number of seconds since 1970 since last scrape of Deployments metrics from BOSH.",
+			ConstLabels: constLabels,
 		},
 	)
 
 	lastDeploymentsScrapeDurationSecondsMetric := prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Namespace: namespace,
-			Subsystem: "",
-			Name:      "last_deployments_scrape_duration_seconds",
-			Help:      "Duration of the last scrape of Deployments metrics from BOSH.",
-			ConstLabels: prometheus.Labels{
-				"environment": environment,
-				"bosh_name":   boshName,
-				"bosh_uuid":   boshUUID,
-			},
+			Namespace:   namespace,
+			Subsystem:   "",
+			Name:        "last_deployments_scrape_duration_seconds",
+			Help:        "Duration of the last scrape of Deployments metrics from BOSH.",
+			ConstLabels: constLabels,
 		},
 	)
 
 	collector := &DeploymentsCollector{
-		deploymentReleaseInfoMetric:                deploymentReleaseInfoMetric,
-		deploymentStemcellInfoMetric:               deploymentStemcellInfoMetric,
-		deploymentInstanceCountMetric:              deploymentInstanceCountMetric,
+		deploymentReleaseInfoDesc:                  deploymentReleaseInfoDesc,
+		deploymentReleaseCreatedDesc:               deploymentReleaseCreatedDesc,
+		deploymentStemcellInfoDesc:                 deploymentStemcellInfoDesc,
+		deploymentStemcellCreatedDesc:              deploymentStemcellCreatedDesc,
+		deploymentInstanceCountDesc:                deploymentInstanceCountDesc,
+		jobProcessCPUTotalDesc:                     jobProcessCPUTotalDesc,
+		jobProcessMemKBDesc:                        jobProcessMemKBDesc,
+		jobProcessUptimeSecondsDesc:                jobProcessUptimeSecondsDesc,
+		jobProcessHealthyDesc:                      jobProcessHealthyDesc,
+		lastDeploymentsScrapeErrorDesc:             lastDeploymentsScrapeErrorDesc,
+		scrapeStaleMetric:                          scrapeStaleMetric,
 		lastDeploymentsScrapeTimestampMetric:       lastDeploymentsScrapeTimestampMetric,
 		lastDeploymentsScrapeDurationSecondsMetric: lastDeploymentsScrapeDurationSecondsMetric,
+		logger:             logger,
+		openMetricsEnabled: openMetricsEnabled,
+		namespace:          namespace,
 	}
 	return collector
 }
 
-func (c *DeploymentsCollector) Collect(deployments []deployments.DeploymentInfo, ch chan<- prometheus.Metric) error {
+// Collect renders releases, stemcells, instance counts and job process
+// metrics for every deployment in the snapshot, plus the scrape-level
+// gauges (staleness, error-per-deployment, timestamp, duration).
+func (c *DeploymentsCollector) Collect(snapshot *deployments.Snapshot, ch chan<- prometheus.Metric) error {
 	var begun = time.Now()
 
-	c.deploymentReleaseInfoMetric.Reset()
-	c.deploymentStemcellInfoMetric.Reset()
-
-	for _, deployment := range deployments {
+	for _, deployment := range snapshot.Deployments {
 		c.reportDeploymentReleaseInfoMetrics(deployment, ch)
 		c.reportDeploymentStemcellInfoMetrics(deployment, ch)
 		c.reportDeploymentInstanceCountMetrics(deployment, ch)
+		c.reportDeploymentProcessMetrics(deployment, ch)
+		c.reportDeploymentScrapeErrorMetric(deployment, ch)
 	}
 
-	c.deploymentReleaseInfoMetric.Collect(ch)
-	c.deploymentStemcellInfoMetric.Collect(ch)
-	c.deploymentInstanceCountMetric.Collect(ch)
+	scrapeStale := float64(0)
+	if snapshot.Stale {
+		scrapeStale = float64(1)
+	}
+	c.scrapeStaleMetric.Set(scrapeStale)
+	c.scrapeStaleMetric.Collect(ch)
 
 	c.lastDeploymentsScrapeTimestampMetric.Set(float64(time.Now().Unix()))
 	c.lastDeploymentsScrapeTimestampMetric.Collect(ch)
@@ -131,9 +204,17 @@ func (c *DeploymentsCollector) Collect(deployments []deployments.DeploymentInfo,
 }
 
 func (c *DeploymentsCollector) Describe(ch chan<- *prometheus.Desc) {
-	c.deploymentReleaseInfoMetric.Describe(ch)
-	c.deploymentStemcellInfoMetric.Describe(ch)
-	c.deploymentInstanceCountMetric.Describe(ch)
+	ch <- c.deploymentReleaseInfoDesc
+	ch <- c.deploymentReleaseCreatedDesc
+	ch <- c.deploymentStemcellInfoDesc
+	ch <- c.deploymentStemcellCreatedDesc
+	ch <- c.deploymentInstanceCountDesc
+	ch <- c.jobProcessCPUTotalDesc
+	ch <- c.jobProcessMemKBDesc
+	ch <- c.jobProcessUptimeSecondsDesc
+	ch <- c.jobProcessHealthyDesc
+	ch <- c.lastDeploymentsScrapeErrorDesc
+	c.scrapeStaleMetric.Describe(ch)
 	c.lastDeploymentsScrapeTimestampMetric.Describe(ch)
 	c.lastDeploymentsScrapeDurationSecondsMetric.Describe(ch)
 }
@@ -142,12 +223,30 @@ func (c *DeploymentsCollector) reportDeploymentReleaseInfoMetrics(
 	deployment deployments.DeploymentInfo,
 	ch chan<- prometheus.Metric,
 ) {
+	// client_golang has no ValueType for OpenMetrics Info metrics, so this
+	// path always renders the classic "gauge with a constant 1" idiom; the
+	// real `# TYPE ..._info info` exposition is produced by
+	// WriteOpenMetricsInfo instead. See openmetrics_info.go.
 	for _, release := range deployment.Releases {
-		c.deploymentReleaseInfoMetric.WithLabelValues(
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentReleaseInfoDesc,
+			prometheus.GaugeValue,
+			float64(1),
 			deployment.Name,
 			release.Name,
 			release.Version,
-		).Set(float64(1))
+		)
+
+		if c.openMetricsEnabled && !release.UploadedAt.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.deploymentReleaseCreatedDesc,
+				prometheus.GaugeValue,
+				float64(release.UploadedAt.Unix()),
+				deployment.Name,
+				release.Name,
+				release.Version,
+			)
+		}
 	}
 }
 
@@ -156,12 +255,27 @@ func (c *DeploymentsCollector) reportDeploymentStemcellInfoMetrics(
 	ch chan<- prometheus.Metric,
 ) {
 	for _, stemcell := range deployment.Stemcells {
-		c.deploymentStemcellInfoMetric.WithLabelValues(
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentStemcellInfoDesc,
+			prometheus.GaugeValue,
+			float64(1),
 			deployment.Name,
 			stemcell.Name,
 			stemcell.Version,
 			stemcell.OSName,
-		).Set(float64(1))
+		)
+
+		if c.openMetricsEnabled && !stemcell.UploadedAt.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				c.deploymentStemcellCreatedDesc,
+				prometheus.GaugeValue,
+				float64(stemcell.UploadedAt.Unix()),
+				deployment.Name,
+				stemcell.Name,
+				stemcell.Version,
+				stemcell.OSName,
+			)
+		}
 	}
 }
 
@@ -176,9 +290,86 @@ func (c *DeploymentsCollector) reportDeploymentInstanceCountMetrics(
 	}
 
 	for vm_type, count := range vm_type_count {
-		c.deploymentInstanceCountMetric.WithLabelValues(
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentInstanceCountDesc,
+			prometheus.GaugeValue,
+			float64(count),
 			deployment.Name,
 			vm_type,
-		).Set(float64(count))
+		)
 	}
 }
+
+func (c *DeploymentsCollector) reportDeploymentProcessMetrics(
+	deployment deployments.DeploymentInfo,
+	ch chan<- prometheus.Metric,
+) {
+	for _, instance := range deployment.Instances {
+		for _, process := range instance.Processes {
+			ch <- prometheus.MustNewConstMetric(
+				c.jobProcessCPUTotalDesc,
+				prometheus.GaugeValue,
+				process.CPUTotal,
+				deployment.Name,
+				instance.Name,
+				instance.ID,
+				process.Name,
+			)
+
+			ch <- prometheus.MustNewConstMetric(
+				c.jobProcessMemKBDesc,
+				prometheus.GaugeValue,
+				float64(process.MemKB),
+				deployment.Name,
+				instance.Name,
+				instance.ID,
+				process.Name,
+			)
+
+			ch <- prometheus.MustNewConstMetric(
+				c.jobProcessUptimeSecondsDesc,
+				prometheus.GaugeValue,
+				float64(process.Uptime),
+				deployment.Name,
+				instance.Name,
+				instance.ID,
+				process.Name,
+			)
+
+			healthy := float64(0)
+			if process.State == "running" {
+				healthy = float64(1)
+			}
+			ch <- prometheus.MustNewConstMetric(
+				c.jobProcessHealthyDesc,
+				prometheus.GaugeValue,
+				healthy,
+				deployment.Name,
+				instance.Name,
+				instance.ID,
+				process.Name,
+			)
+		}
+	}
+}
+
+func (c *DeploymentsCollector) reportDeploymentScrapeErrorMetric(
+	deployment deployments.DeploymentInfo,
+	ch chan<- prometheus.Metric,
+) {
+	scrapeError := float64(0)
+	if deployment.ScrapeError != nil {
+		scrapeError = float64(1)
+		c.logger.Warn("deployment scrape failed",
+			"deployment", deployment.Name,
+			"err", deployment.ScrapeError,
+		)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.lastDeploymentsScrapeErrorDesc,
+		prometheus.GaugeValue,
+		scrapeError,
+		deployment.Name,
+	)
+}
@@ -0,0 +1,118 @@
+package collectors
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/bosh_exporter/deployments"
+)
+
+// client_golang (checked through v1.24.1) only defines CounterValue,
+// GaugeValue and UntypedValue for prometheus.NewConstMetric; there is no
+// ValueType for OpenMetrics Info metrics, and UntypedValue is exposed as
+// OpenMetrics "unknown", not "info". A real `# TYPE ..._info info` line
+// therefore can't be produced through prometheus.Collector/Registry at
+// all, so WriteOpenMetricsInfo writes that text directly. A caller that
+// wants true Info semantics for release_info/stemcell_info (e.g. the
+// exporter's HTTP handler, when --web.openmetrics is set) must merge this
+// output into the scrape response alongside whatever the standard
+// Registry produces for every other metric.
+func (c *DeploymentsCollector) WriteOpenMetricsInfo(snapshot *deployments.Snapshot, w io.Writer) error {
+	releaseFamily := openMetricsInfoFamily{
+		help: "Labeled BOSH Deployment Release Info with a constant '1' value.",
+	}
+	stemcellFamily := openMetricsInfoFamily{
+		help: "Labeled BOSH Deployment Stemcell Info with a constant '1' value.",
+	}
+
+	for _, deployment := range snapshot.Deployments {
+		for _, release := range deployment.Releases {
+			releaseFamily.series = append(releaseFamily.series, openMetricsInfoSeries{
+				labels: map[string]string{
+					"bosh_deployment":      deployment.Name,
+					"bosh_release_name":    release.Name,
+					"bosh_release_version": release.Version,
+				},
+				createdUnix: release.UploadedAt.Unix(),
+				hasCreated:  !release.UploadedAt.IsZero(),
+			})
+		}
+
+		for _, stemcell := range deployment.Stemcells {
+			stemcellFamily.series = append(stemcellFamily.series, openMetricsInfoSeries{
+				labels: map[string]string{
+					"bosh_deployment":       deployment.Name,
+					"bosh_stemcell_name":    stemcell.Name,
+					"bosh_stemcell_version": stemcell.Version,
+					"bosh_stemcell_os_name": stemcell.OSName,
+				},
+				createdUnix: stemcell.UploadedAt.Unix(),
+				hasCreated:  !stemcell.UploadedAt.IsZero(),
+			})
+		}
+	}
+
+	releaseInfoName := prometheus.BuildFQName(c.namespace, "deployment", "release_info")
+	stemcellInfoName := prometheus.BuildFQName(c.namespace, "deployment", "stemcell_info")
+
+	if err := writeOpenMetricsInfoFamily(w, releaseInfoName, releaseFamily); err != nil {
+		return err
+	}
+	return writeOpenMetricsInfoFamily(w, stemcellInfoName, stemcellFamily)
+}
+
+type openMetricsInfoSeries struct {
+	labels      map[string]string
+	createdUnix int64
+	hasCreated  bool
+}
+
+type openMetricsInfoFamily struct {
+	help   string
+	series []openMetricsInfoSeries
+}
+
+func writeOpenMetricsInfoFamily(w io.Writer, name string, family openMetricsInfoFamily) error {
+	if len(family.series) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s info\n", name, family.help, name); err != nil {
+		return err
+	}
+
+	for _, series := range family.series {
+		if _, err := fmt.Fprintf(w, "%s%s 1\n", name, formatOpenMetricsLabels(series.labels)); err != nil {
+			return err
+		}
+		if series.hasCreated {
+			if _, err := fmt.Fprintf(w, "%s_created%s %d\n", name, formatOpenMetricsLabels(series.labels), series.createdUnix); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatOpenMetricsLabels renders a label set in the `{k="v",...}` form,
+// sorted by key so output is deterministic across runs.
+func formatOpenMetricsLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := "{"
+	for i, k := range keys {
+		if i > 0 {
+			out += ","
+		}
+		out += fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return out + "}"
+}
@@ -0,0 +1,47 @@
+package collectors
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bosh-prometheus/bosh_exporter/deployments"
+)
+
+func TestWriteOpenMetricsInfoGoldenOutput(t *testing.T) {
+	collector := NewDeploymentsCollector("bosh", "test", "bosh-name", "bosh-uuid", nil, true)
+
+	uploadedAt := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
+	snapshot := &deployments.Snapshot{
+		Deployments: []deployments.DeploymentInfo{
+			{
+				Name: "dep1",
+				Releases: []deployments.Release{
+					{Name: "release1", Version: "1.2.3", UploadedAt: uploadedAt},
+				},
+				Stemcells: []deployments.Stemcell{
+					{Name: "stemcell1", Version: "1", OSName: "ubuntu-jammy"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := collector.WriteOpenMetricsInfo(snapshot, &buf); err != nil {
+		t.Fatalf("WriteOpenMetricsInfo() = %v, want nil", err)
+	}
+
+	want := `# HELP bosh_deployment_release_info Labeled BOSH Deployment Release Info with a constant '1' value.
+# TYPE bosh_deployment_release_info info
+bosh_deployment_release_info{bosh_deployment="dep1",bosh_release_name="release1",bosh_release_version="1.2.3"} 1
+bosh_deployment_release_info_created{bosh_deployment="dep1",bosh_release_name="release1",bosh_release_version="1.2.3"} ` + strconv.FormatInt(uploadedAt.Unix(), 10) + `
+# HELP bosh_deployment_stemcell_info Labeled BOSH Deployment Stemcell Info with a constant '1' value.
+# TYPE bosh_deployment_stemcell_info info
+bosh_deployment_stemcell_info{bosh_deployment="dep1",bosh_stemcell_name="stemcell1",bosh_stemcell_os_name="ubuntu-jammy",bosh_stemcell_version="1"} 1
+`
+
+	if buf.String() != want {
+		t.Fatalf("WriteOpenMetricsInfo() output =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,163 @@
+package collectors
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bosh-prometheus/bosh_exporter/deployments"
+)
+
+type ProblemsCollector struct {
+	deploymentProblemsCountDesc             *prometheus.Desc
+	deploymentTasksRunningDesc              *prometheus.Desc
+	deploymentLastTaskTimestampDesc         *prometheus.Desc
+	lastProblemsScrapeTimestampMetric       prometheus.Gauge
+	lastProblemsScrapeDurationSecondsMetric prometheus.Gauge
+}
+
+func NewProblemsCollector(
+	namespace string,
+	environment string,
+	boshName string,
+	boshUUID string,
+) *ProblemsCollector {
+	constLabels := prometheus.Labels{
+		"environment": environment,
+		"bosh_name":   boshName,
+		"bosh_uuid":   boshUUID,
+	}
+
+	deploymentProblemsCountDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "problems_count"),
+		"Number of unresolved cloud-check problems for this deployment.",
+		[]string{"bosh_deployment", "type"},
+		constLabels,
+	)
+
+	deploymentTasksRunningDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "tasks_running"),
+		"Number of BOSH Director tasks for this deployment, grouped by state.",
+		[]string{"bosh_deployment", "state"},
+		constLabels,
+	)
+
+	deploymentLastTaskTimestampDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "deployment", "last_task_timestamp"),
+		"Number of seconds since 1970 since the last BOSH Director task for this deployment.",
+		[]string{"bosh_deployment"},
+		constLabels,
+	)
+
+	lastProblemsScrapeTimestampMetric := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "",
+			Name:        "last_problems_scrape_timestamp",
+			Help:        "Number of seconds since 1970 since last scrape of Problems metrics from BOSH.",
+			ConstLabels: constLabels,
+		},
+	)
+
+	lastProblemsScrapeDurationSecondsMetric := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "",
+			Name:        "last_problems_scrape_duration_seconds",
+			Help:        "Duration of the last scrape of Problems metrics from BOSH.",
+			ConstLabels: constLabels,
+		},
+	)
+
+	collector := &ProblemsCollector{
+		deploymentProblemsCountDesc:             deploymentProblemsCountDesc,
+		deploymentTasksRunningDesc:              deploymentTasksRunningDesc,
+		deploymentLastTaskTimestampDesc:         deploymentLastTaskTimestampDesc,
+		lastProblemsScrapeTimestampMetric:       lastProblemsScrapeTimestampMetric,
+		lastProblemsScrapeDurationSecondsMetric: lastProblemsScrapeDurationSecondsMetric,
+	}
+	return collector
+}
+
+// Collect renders unresolved cloud-check problem counts and task state
+// counts for every deployment in the snapshot, plus the scrape timestamp
+// and duration gauges.
+func (c *ProblemsCollector) Collect(snapshot *deployments.Snapshot, ch chan<- prometheus.Metric) error {
+	var begun = time.Now()
+
+	for _, deployment := range snapshot.Deployments {
+		c.reportDeploymentProblemsMetrics(deployment, ch)
+		c.reportDeploymentTasksMetrics(deployment, ch)
+	}
+
+	c.lastProblemsScrapeTimestampMetric.Set(float64(time.Now().Unix()))
+	c.lastProblemsScrapeTimestampMetric.Collect(ch)
+
+	c.lastProblemsScrapeDurationSecondsMetric.Set(time.Since(begun).Seconds())
+	c.lastProblemsScrapeDurationSecondsMetric.Collect(ch)
+
+	return nil
+}
+
+func (c *ProblemsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.deploymentProblemsCountDesc
+	ch <- c.deploymentTasksRunningDesc
+	ch <- c.deploymentLastTaskTimestampDesc
+	c.lastProblemsScrapeTimestampMetric.Describe(ch)
+	c.lastProblemsScrapeDurationSecondsMetric.Describe(ch)
+}
+
+func (c *ProblemsCollector) reportDeploymentProblemsMetrics(
+	deployment deployments.DeploymentInfo,
+	ch chan<- prometheus.Metric,
+) {
+	problemTypeCount := make(map[string]int)
+
+	for _, problem := range deployment.Problems {
+		problemTypeCount[problem.Type] = problemTypeCount[problem.Type] + 1
+	}
+
+	for problemType, count := range problemTypeCount {
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentProblemsCountDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			deployment.Name,
+			problemType,
+		)
+	}
+}
+
+func (c *ProblemsCollector) reportDeploymentTasksMetrics(
+	deployment deployments.DeploymentInfo,
+	ch chan<- prometheus.Metric,
+) {
+	taskStateCount := make(map[string]int)
+	var lastTaskTimestamp int64
+
+	for _, task := range deployment.Tasks {
+		taskStateCount[task.State] = taskStateCount[task.State] + 1
+		if task.Timestamp > lastTaskTimestamp {
+			lastTaskTimestamp = task.Timestamp
+		}
+	}
+
+	for state, count := range taskStateCount {
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentTasksRunningDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			deployment.Name,
+			state,
+		)
+	}
+
+	if lastTaskTimestamp > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			c.deploymentLastTaskTimestampDesc,
+			prometheus.GaugeValue,
+			float64(lastTaskTimestamp),
+			deployment.Name,
+		)
+	}
+}
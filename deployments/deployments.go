@@ -0,0 +1,83 @@
+package deployments
+
+import "time"
+
+type DeploymentInfo struct {
+	Name      string
+	Releases  []Release
+	Stemcells []Stemcell
+	Instances []Instance
+	Problems  []Problem
+	Tasks     []Task
+
+	// ScrapeError holds the error encountered while fetching this
+	// deployment's data from the BOSH Director, if any. It is nil for a
+	// deployment that scraped successfully.
+	ScrapeError error
+}
+
+type Release struct {
+	Name    string
+	Version string
+
+	// UploadedAt is when this release version was uploaded to the BOSH
+	// Director, used to populate the release_info `_created` timestamp.
+	UploadedAt time.Time
+}
+
+type Stemcell struct {
+	Name    string
+	Version string
+	OSName  string
+
+	// UploadedAt is when this stemcell version was uploaded to the BOSH
+	// Director, used to populate the stemcell_info `_created` timestamp.
+	UploadedAt time.Time
+}
+
+type Instance struct {
+	Name      string
+	ID        string
+	VMType    string
+	Processes []ProcessInfo
+}
+
+// ProcessInfo represents a single job process, as returned in the
+// `processes` array of the director's VM/instance info.
+type ProcessInfo struct {
+	Name     string
+	State    string
+	CPUTotal float64
+	MemKB    uint64
+	Uptime   uint64
+}
+
+// Problem represents a single BOSH Director cloud-check problem, as
+// returned by the `/deployments/{name}/problems` endpoint.
+type Problem struct {
+	ID          int
+	Type        string
+	Description string
+}
+
+// Task represents a single BOSH Director task, as returned by the
+// `/tasks` endpoint, scoped to a deployment.
+type Task struct {
+	ID          int
+	State       string
+	Description string
+	Timestamp   int64
+}
+
+// Snapshot is an immutable, point-in-time view of the deployments fetched
+// from the BOSH Director during a single scrape. Collectors render it
+// directly rather than mutating shared metric state, so a Collect() call
+// never observes data from two different fetches.
+type Snapshot struct {
+	Deployments []DeploymentInfo
+
+	// Stale is true when this Snapshot was served from cache because the
+	// BOSH Director client's circuit-breaker was open, rather than being
+	// freshly fetched for this scrape.
+	Stale bool
+}
@@ -0,0 +1,136 @@
+package deployments
+
+import (
+	"github.com/bosh-prometheus/bosh_exporter/pkg/resilience"
+)
+
+// DirectorClient is the subset of the BOSH Director client that Fetcher
+// needs to build a Snapshot: listing deployments, then the releases,
+// stemcells, VM infos, problems and tasks for each one.
+type DirectorClient interface {
+	ListDeploymentNames() ([]string, error)
+	Releases(deploymentName string) ([]Release, error)
+	Stemcells(deploymentName string) ([]Stemcell, error)
+	VMInfos(deploymentName string) ([]Instance, error)
+	Problems(deploymentName string) ([]Problem, error)
+	Tasks(deploymentName string) ([]Task, error)
+}
+
+// Fetcher builds a Snapshot from the BOSH Director, wrapping each Director
+// call in a resilience.Runner so that calls for an operation whose breaker
+// is open are rejected at once instead of reaching the Director. Calls are
+// not timed out: a slow Director call blocks Fetch until it returns. When an
+// operation's breaker is open, Fetch serves the last good Snapshot with
+// Stale set instead of blocking or returning an error.
+type Fetcher struct {
+	client DirectorClient
+	runner *resilience.Runner
+
+	last *Snapshot
+}
+
+// NewFetcher creates a Fetcher. runner should be shared with anything else
+// calling the same Director so that its per-operation breakers see the
+// full picture of failures against that operation.
+func NewFetcher(client DirectorClient, runner *resilience.Runner) *Fetcher {
+	return &Fetcher{
+		client: client,
+		runner: runner,
+		last:   &Snapshot{},
+	}
+}
+
+// Fetch returns a fresh Snapshot of every deployment's releases,
+// stemcells, instances, problems and tasks. If the Director is unhealthy
+// enough that the breaker for any of the underlying calls is open, Fetch
+// instead returns the last successful Snapshot with Stale set to true.
+func (f *Fetcher) Fetch() (*Snapshot, error) {
+	var names []string
+	err := f.runner.Run("deployments_list", func() error {
+		var runErr error
+		names, runErr = f.client.ListDeploymentNames()
+		return runErr
+	})
+	if err != nil {
+		return f.staleOrError(err)
+	}
+
+	snapshot := &Snapshot{}
+	for _, name := range names {
+		deployment := DeploymentInfo{Name: name}
+
+		err := f.runner.Run("releases", func() error {
+			var runErr error
+			deployment.Releases, runErr = f.client.Releases(name)
+			return runErr
+		})
+		if err != nil {
+			if _, open := err.(resilience.ErrBreakerOpen); open {
+				return f.staleOrError(err)
+			}
+			deployment.ScrapeError = err
+		}
+
+		err = f.runner.Run("stemcells", func() error {
+			var runErr error
+			deployment.Stemcells, runErr = f.client.Stemcells(name)
+			return runErr
+		})
+		if err != nil {
+			if _, open := err.(resilience.ErrBreakerOpen); open {
+				return f.staleOrError(err)
+			}
+			deployment.ScrapeError = err
+		}
+
+		err = f.runner.Run("vm_infos", func() error {
+			var runErr error
+			deployment.Instances, runErr = f.client.VMInfos(name)
+			return runErr
+		})
+		if err != nil {
+			if _, open := err.(resilience.ErrBreakerOpen); open {
+				return f.staleOrError(err)
+			}
+			deployment.ScrapeError = err
+		}
+
+		err = f.runner.Run("problems", func() error {
+			var runErr error
+			deployment.Problems, runErr = f.client.Problems(name)
+			return runErr
+		})
+		if err != nil {
+			if _, open := err.(resilience.ErrBreakerOpen); open {
+				return f.staleOrError(err)
+			}
+			deployment.ScrapeError = err
+		}
+
+		err = f.runner.Run("tasks", func() error {
+			var runErr error
+			deployment.Tasks, runErr = f.client.Tasks(name)
+			return runErr
+		})
+		if err != nil {
+			if _, open := err.(resilience.ErrBreakerOpen); open {
+				return f.staleOrError(err)
+			}
+			deployment.ScrapeError = err
+		}
+
+		snapshot.Deployments = append(snapshot.Deployments, deployment)
+	}
+
+	f.last = snapshot
+	return snapshot, nil
+}
+
+func (f *Fetcher) staleOrError(err error) (*Snapshot, error) {
+	if _, open := err.(resilience.ErrBreakerOpen); open {
+		stale := *f.last
+		stale.Stale = true
+		return &stale, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,136 @@
+package deployments
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bosh-prometheus/bosh_exporter/pkg/resilience"
+)
+
+type fakeDirectorClient struct {
+	names     []string
+	releases  []Release
+	stemcells []Stemcell
+	vmInfos   []Instance
+	problems  []Problem
+	tasks     []Task
+
+	failOn string
+	err    error
+}
+
+func (f *fakeDirectorClient) ListDeploymentNames() ([]string, error) {
+	if f.failOn == "deployments_list" {
+		return nil, f.err
+	}
+	return f.names, nil
+}
+
+func (f *fakeDirectorClient) Releases(string) ([]Release, error) {
+	if f.failOn == "releases" {
+		return nil, f.err
+	}
+	return f.releases, nil
+}
+
+func (f *fakeDirectorClient) Stemcells(string) ([]Stemcell, error) {
+	if f.failOn == "stemcells" {
+		return nil, f.err
+	}
+	return f.stemcells, nil
+}
+
+func (f *fakeDirectorClient) VMInfos(string) ([]Instance, error) {
+	if f.failOn == "vm_infos" {
+		return nil, f.err
+	}
+	return f.vmInfos, nil
+}
+
+func (f *fakeDirectorClient) Problems(string) ([]Problem, error) {
+	if f.failOn == "problems" {
+		return nil, f.err
+	}
+	return f.problems, nil
+}
+
+func (f *fakeDirectorClient) Tasks(string) ([]Task, error) {
+	if f.failOn == "tasks" {
+		return nil, f.err
+	}
+	return f.tasks, nil
+}
+
+func TestFetchPopulatesEveryField(t *testing.T) {
+	client := &fakeDirectorClient{
+		names:     []string{"dep1"},
+		releases:  []Release{{Name: "release1", Version: "1"}},
+		stemcells: []Stemcell{{Name: "stemcell1", Version: "1"}},
+		vmInfos:   []Instance{{Name: "job1", ID: "0"}},
+		problems:  []Problem{{ID: 1, Type: "unresponsive_agent"}},
+		tasks:     []Task{{ID: 1, State: "done"}},
+	}
+	fetcher := NewFetcher(client, resilience.NewRunner("bosh", "env", "name", "uuid", resilience.DefaultConfig))
+
+	snapshot, err := fetcher.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() = %v, want nil", err)
+	}
+	if len(snapshot.Deployments) != 1 {
+		t.Fatalf("len(snapshot.Deployments) = %d, want 1", len(snapshot.Deployments))
+	}
+
+	deployment := snapshot.Deployments[0]
+	if len(deployment.Releases) != 1 {
+		t.Errorf("Releases = %v, want 1 entry", deployment.Releases)
+	}
+	if len(deployment.Stemcells) != 1 {
+		t.Errorf("Stemcells = %v, want 1 entry", deployment.Stemcells)
+	}
+	if len(deployment.Instances) != 1 {
+		t.Errorf("Instances = %v, want 1 entry", deployment.Instances)
+	}
+	if len(deployment.Problems) != 1 {
+		t.Errorf("Problems = %v, want 1 entry", deployment.Problems)
+	}
+	if len(deployment.Tasks) != 1 {
+		t.Errorf("Tasks = %v, want 1 entry", deployment.Tasks)
+	}
+	if deployment.ScrapeError != nil {
+		t.Errorf("ScrapeError = %v, want nil", deployment.ScrapeError)
+	}
+}
+
+func TestFetchServesStaleSnapshotWhenBreakerOpen(t *testing.T) {
+	client := &fakeDirectorClient{names: []string{"dep1"}}
+	runner := resilience.NewRunner("bosh", "env", "name", "uuid", resilience.Config{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Hour,
+	})
+	fetcher := NewFetcher(client, runner)
+
+	good, err := fetcher.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() = %v, want nil", err)
+	}
+
+	client.failOn = "deployments_list"
+	client.err = errors.New("director unreachable")
+
+	// Trip the deployments_list breaker open.
+	if _, err := fetcher.Fetch(); err == nil {
+		t.Fatal("Fetch() = nil error, want the director error while the breaker is still closed")
+	}
+
+	stale, err := fetcher.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch() with open breaker = %v, want nil", err)
+	}
+	if !stale.Stale {
+		t.Fatal("Stale = false, want true when serving a cached Snapshot")
+	}
+	if len(stale.Deployments) != len(good.Deployments) {
+		t.Fatalf("stale.Deployments = %v, want the last good Snapshot's %v", stale.Deployments, good.Deployments)
+	}
+}
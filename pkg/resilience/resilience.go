@@ -0,0 +1,230 @@
+// Package resilience wraps calls to the BOSH Director in a small
+// circuit-breaker, recording request/duration metrics and tripping a
+// breaker after consecutive failed calls so that further calls to a
+// failing Director are rejected at once. Calls are not timed out: a slow
+// call that returns without error counts as a success, and a call that
+// never returns blocks its caller.
+package resilience
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// State is the current state of a breaker.
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+// Config controls when a breaker opens and how long it stays open before
+// allowing a probe request through.
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that trips
+	// the breaker open.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before moving to
+	// half-open and allowing a single probe request through.
+	OpenTimeout time.Duration
+}
+
+// DefaultConfig matches the defaults used for the BOSH Director client.
+var DefaultConfig = Config{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+}
+
+// breaker tracks circuit-breaker state for a single operation. Runner keeps
+// one of these per operation name so a failure storm on, say, "tasks"
+// cannot trip the breaker for "deployments_list".
+type breaker struct {
+	mu               sync.Mutex
+	state            State
+	consecutiveFails int
+	openedAt         time.Time
+	// probing is true while a half-open caller's probe request is still in
+	// flight, so only one caller at a time is let through to test the
+	// Director instead of the whole half-open window being unguarded.
+	probing bool
+}
+
+func (b *breaker) allow(cfg Config) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if time.Since(b.openedAt) < cfg.OpenTimeout {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = false
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+
+	return true
+}
+
+func (b *breaker) recordFailure(cfg Config) State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.consecutiveFails++
+	if b.state == StateHalfOpen || b.consecutiveFails >= cfg.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+	return b.state
+}
+
+func (b *breaker) recordSuccess() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	b.consecutiveFails = 0
+	b.state = StateClosed
+	return b.state
+}
+
+// Runner wraps calls to the BOSH Director with a circuit-breaker and
+// exposes Prometheus metrics describing the outcome of those calls. Each
+// operation name passed to Run gets its own independent breaker.
+type Runner struct {
+	cfg Config
+
+	requestsTotalMetric   *prometheus.CounterVec
+	requestDurationMetric *prometheus.HistogramVec
+	breakerStateMetric    *prometheus.GaugeVec
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+// NewRunner creates a Runner whose metrics are registered under the given
+// namespace and labeled with the exporter's environment/bosh_name/bosh_uuid.
+func NewRunner(namespace string, environment string, boshName string, boshUUID string, cfg Config) *Runner {
+	constLabels := prometheus.Labels{
+		"environment": environment,
+		"bosh_name":   boshName,
+		"bosh_uuid":   boshUUID,
+	}
+
+	requestsTotalMetric := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "director_client",
+			Name:        "requests_total",
+			Help:        "Total number of requests made to the BOSH Director, by operation and result.",
+			ConstLabels: constLabels,
+		},
+		[]string{"operation", "result"},
+	)
+
+	requestDurationMetric := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   "director_client",
+			Name:        "request_duration_seconds",
+			Help:        "Duration of requests made to the BOSH Director, by operation.",
+			ConstLabels: constLabels,
+		},
+		[]string{"operation"},
+	)
+
+	breakerStateMetric := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "director_client",
+			Name:        "breaker_state",
+			Help:        "State of the BOSH Director client circuit-breaker (0 closed, 1 open, 2 half-open).",
+			ConstLabels: constLabels,
+		},
+		[]string{"operation"},
+	)
+
+	return &Runner{
+		cfg:                   cfg,
+		requestsTotalMetric:   requestsTotalMetric,
+		requestDurationMetric: requestDurationMetric,
+		breakerStateMetric:    breakerStateMetric,
+		breakers:              make(map[string]*breaker),
+	}
+}
+
+// ErrBreakerOpen is returned by Run when the breaker is open and the call
+// was rejected without being attempted against the Director.
+type ErrBreakerOpen struct {
+	Operation string
+}
+
+func (e ErrBreakerOpen) Error() string {
+	return "resilience: breaker open for operation " + e.Operation
+}
+
+// Run executes fn against the BOSH Director under the given operation
+// name, recording request/duration metrics and tripping that operation's
+// breaker open after too many consecutive failures. While an operation's
+// breaker is open, Run rejects calls for that operation immediately with
+// ErrBreakerOpen instead of invoking fn; other operations are unaffected.
+func (r *Runner) Run(operation string, fn func() error) error {
+	b := r.breakerFor(operation)
+
+	if !b.allow(r.cfg) {
+		r.requestsTotalMetric.WithLabelValues(operation, "rejected").Inc()
+		return ErrBreakerOpen{Operation: operation}
+	}
+
+	begun := time.Now()
+	err := fn()
+	r.requestDurationMetric.WithLabelValues(operation).Observe(time.Since(begun).Seconds())
+
+	if err != nil {
+		r.requestsTotalMetric.WithLabelValues(operation, "failure").Inc()
+		state := b.recordFailure(r.cfg)
+		r.breakerStateMetric.WithLabelValues(operation).Set(float64(state))
+		return err
+	}
+
+	r.requestsTotalMetric.WithLabelValues(operation, "success").Inc()
+	state := b.recordSuccess()
+	r.breakerStateMetric.WithLabelValues(operation).Set(float64(state))
+	return nil
+}
+
+func (r *Runner) breakerFor(operation string) *breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[operation]
+	if !ok {
+		b = &breaker{}
+		r.breakers[operation] = b
+	}
+	return b
+}
+
+// Describe implements prometheus.Collector.
+func (r *Runner) Describe(ch chan<- *prometheus.Desc) {
+	r.requestsTotalMetric.Describe(ch)
+	r.requestDurationMetric.Describe(ch)
+	r.breakerStateMetric.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *Runner) Collect(ch chan<- prometheus.Metric) {
+	r.requestsTotalMetric.Collect(ch)
+	r.requestDurationMetric.Collect(ch)
+	r.breakerStateMetric.Collect(ch)
+}
@@ -0,0 +1,107 @@
+package resilience
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+	}
+}
+
+func TestRunBreakerIsPerOperation(t *testing.T) {
+	runner := NewRunner("bosh", "env", "name", "uuid", testConfig())
+
+	failing := errors.New("boom")
+	for i := 0; i < 2; i++ {
+		if err := runner.Run("tasks", func() error { return failing }); err != failing {
+			t.Fatalf("Run(tasks) = %v, want %v", err, failing)
+		}
+	}
+
+	if err := runner.Run("tasks", func() error { return nil }); !errors.As(err, &ErrBreakerOpen{}) {
+		t.Fatalf("Run(tasks) after threshold = %v, want ErrBreakerOpen", err)
+	}
+
+	called := false
+	if err := runner.Run("releases", func() error { called = true; return nil }); err != nil {
+		t.Fatalf("Run(releases) = %v, want nil", err)
+	}
+	if !called {
+		t.Fatal("Run(releases) did not invoke fn; its breaker should be independent of tasks'")
+	}
+}
+
+func TestRunHalfOpenAllowsSingleProbe(t *testing.T) {
+	cfg := testConfig()
+	runner := NewRunner("bosh", "env", "name", "uuid", cfg)
+
+	failing := errors.New("boom")
+	for i := 0; i < cfg.FailureThreshold; i++ {
+		_ = runner.Run("tasks", func() error { return failing })
+	}
+
+	if err := runner.Run("tasks", func() error { return nil }); !errors.As(err, &ErrBreakerOpen{}) {
+		t.Fatalf("Run(tasks) while open = %v, want ErrBreakerOpen", err)
+	}
+
+	time.Sleep(cfg.OpenTimeout * 2)
+
+	var probesLet int
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	block := make(chan struct{})
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := runner.Run("tasks", func() error {
+				mu.Lock()
+				probesLet++
+				mu.Unlock()
+				<-block
+				return nil
+			})
+			if err != nil && !errors.As(err, &ErrBreakerOpen{}) {
+				t.Errorf("Run(tasks) during half-open = %v, want nil or ErrBreakerOpen", err)
+			}
+		}()
+	}
+
+	// Give every goroutine a chance to reach the breaker check before
+	// releasing the single probe that got through.
+	time.Sleep(10 * time.Millisecond)
+	close(block)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if probesLet != 1 {
+		t.Fatalf("probes let through during half-open = %d, want 1", probesLet)
+	}
+}
+
+func TestRunThresholdFailuresAfterSuccessOpenBreaker(t *testing.T) {
+	cfg := testConfig()
+	runner := NewRunner("bosh", "env", "name", "uuid", cfg)
+
+	failing := errors.New("boom")
+	_ = runner.Run("tasks", func() error { return failing })
+
+	if err := runner.Run("tasks", func() error { return nil }); err != nil {
+		t.Fatalf("Run(tasks) = %v, want nil", err)
+	}
+
+	for i := 0; i < cfg.FailureThreshold; i++ {
+		_ = runner.Run("tasks", func() error { return failing })
+	}
+	if err := runner.Run("tasks", func() error { return nil }); !errors.As(err, &ErrBreakerOpen{}) {
+		t.Fatalf("Run(tasks) = %v, want ErrBreakerOpen (FailureThreshold consecutive failures after a success should open the breaker)", err)
+	}
+}